@@ -0,0 +1,129 @@
+package db
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/nknorg/nkn/v2/common"
+)
+
+// fakeOrderedStore is a minimal, in-memory stand-in for the real
+// LevelDB-backed db.IStore: just enough ordered put/seek to
+// benchmark the sequential header schema's read pattern at realistic
+// scale. db.IStore itself isn't defined anywhere in this tree, so a real
+// implementation can't be benchmarked here; this fixture instead
+// measures whether HeaderSeqKey's byte-ordering lets a seek-then-scan
+// read stay cheap as the keyspace grows to 100k heights, which is the
+// property GetStateRoots/blockHashByHeight actually depend on.
+type fakeOrderedStore struct {
+	keys   [][]byte
+	values [][]byte
+}
+
+func (s *fakeOrderedStore) put(key, value []byte) {
+	i := sort.Search(len(s.keys), func(i int) bool { return string(s.keys[i]) >= string(key) })
+	s.keys = append(s.keys, nil)
+	s.values = append(s.values, nil)
+	copy(s.keys[i+1:], s.keys[i:])
+	copy(s.values[i+1:], s.values[i:])
+	s.keys[i] = key
+	s.values[i] = value
+}
+
+// seek returns the index of the first key >= from.
+func (s *fakeOrderedStore) seek(from []byte) int {
+	return sort.Search(len(s.keys), func(i int) bool { return string(s.keys[i]) >= string(from) })
+}
+
+// buildHeaderSeq100k populates a fakeOrderedStore with a sequential
+// header key for every height in [0, 100000), each holding a fixed-size
+// payload standing in for a trimmed block header.
+func buildHeaderSeq100k(b *testing.B) *fakeOrderedStore {
+	b.Helper()
+	const n = 100000
+	const payloadSize = 256 // rough trimmed-header size
+
+	s := &fakeOrderedStore{keys: make([][]byte, 0, n), values: make([][]byte, 0, n)}
+	payload := make([]byte, payloadSize)
+
+	for height := uint32(0); height < n; height++ {
+		var hash common.Uint256
+		hash[0] = byte(height)
+		hash[1] = byte(height >> 8)
+		hash[2] = byte(height >> 16)
+		s.put(HeaderSeqKey(height, hash), payload)
+	}
+
+	return s
+}
+
+// BenchmarkChainRead_header_100k measures a bounded range read (100
+// consecutive heights) against a 100k-height sequential header keyspace,
+// seeking directly to the range start the way GetStateRoots now does,
+// instead of scanning from genesis.
+func BenchmarkChainRead_header_100k(b *testing.B) {
+	s := buildHeaderSeq100k(b)
+	const rangeSize = 100
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fromHeight := uint32(i%(100000-rangeSize)) + 1
+		idx := s.seek(HeaderSeqPrefix(fromHeight))
+
+		count := 0
+		for ; idx < len(s.keys); idx++ {
+			key := s.keys[idx]
+			if len(key) < 9 || key[0] != headerSeqPrefix {
+				break
+			}
+			height := DecodeBlockNumber(key[1:9])
+			if height >= fromHeight+rangeSize {
+				break
+			}
+			count++
+		}
+		if count != rangeSize {
+			b.Fatalf("read %d entries, want %d", count, rangeSize)
+		}
+	}
+}
+
+// BenchmarkChainRead_full_100k measures the same bounded range read as
+// BenchmarkChainRead_header_100k, but also reads every byte of each
+// entry's value payload, standing in for the additional per-entry
+// unmarshal cost a real header/body read would pay (block.Header isn't
+// available in this tree to unmarshal for real).
+func BenchmarkChainRead_full_100k(b *testing.B) {
+	s := buildHeaderSeq100k(b)
+	const rangeSize = 100
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fromHeight := uint32(i%(100000-rangeSize)) + 1
+		idx := s.seek(HeaderSeqPrefix(fromHeight))
+
+		var decodedSum uint64
+		count := 0
+		for ; idx < len(s.keys); idx++ {
+			key := s.keys[idx]
+			if len(key) < 9 || key[0] != headerSeqPrefix {
+				break
+			}
+			height := DecodeBlockNumber(key[1:9])
+			if height >= fromHeight+rangeSize {
+				break
+			}
+
+			// Stand-in for unmarshaling the value payload: touch every
+			// byte the way a real decode would.
+			for _, v := range s.values[idx] {
+				decodedSum += uint64(v)
+			}
+
+			count++
+		}
+		if count != rangeSize {
+			b.Fatalf("read %d entries, want %d", count, rangeSize)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"encoding/binary"
+
+	"github.com/nknorg/nkn/v2/common"
+)
+
+// Sequential-key schema (introduced at DB version 0x02, schemaSeqVersion in
+// chain/store, and later). Headers
+// are keyed by an 8-byte big-endian block number so that height-ordered
+// byte order matches numeric order, which lets iterator-based range reads
+// (header sync, GetStateRoots) stream sequentially through LevelDB instead
+// of doing one random-access seek per height. This mirrors the
+// canonical/ancient-style key layout used by go-ethereum's chain database.
+const (
+	headerSeqPrefix    = 'h' // headerSeqPrefix + num(8) + hash -> header
+	headerNumberPrefix = 'H' // headerNumberPrefix + hash -> num(8)
+)
+
+// EncodeBlockNumber big-endian encodes a block height into 8 bytes, so
+// lexicographic key order matches numeric height order.
+func EncodeBlockNumber(number uint32) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, uint64(number))
+	return enc
+}
+
+// DecodeBlockNumber is the inverse of EncodeBlockNumber.
+func DecodeBlockNumber(enc []byte) uint32 {
+	return uint32(binary.BigEndian.Uint64(enc))
+}
+
+// HeaderSeqKey builds the sequential header key for a given height/hash
+// pair: headerSeqPrefix || num(8) || hash.
+func HeaderSeqKey(number uint32, hash common.Uint256) []byte {
+	key := make([]byte, 0, 1+8+len(hash))
+	key = append(key, headerSeqPrefix)
+	key = append(key, EncodeBlockNumber(number)...)
+	key = append(key, hash[:]...)
+	return key
+}
+
+// HeaderSeqPrefix returns the key prefix shared by every HeaderSeqKey at
+// the given height, for prefix-iteration lookups that don't already know
+// the block hash.
+func HeaderSeqPrefix(number uint32) []byte {
+	key := make([]byte, 0, 1+8)
+	key = append(key, headerSeqPrefix)
+	key = append(key, EncodeBlockNumber(number)...)
+	return key
+}
+
+// HeaderSeqKeyPrefix returns the single-byte prefix shared by every
+// sequential header key, for a full ascending-height scan of the header
+// keyspace (heights sort correctly since the number is big-endian).
+func HeaderSeqKeyPrefix() []byte {
+	return []byte{headerSeqPrefix}
+}
+
+// HeaderNumberKey maps a block hash to its height: headerNumberPrefix || hash.
+func HeaderNumberKey(hash common.Uint256) []byte {
+	key := make([]byte, 0, 1+len(hash))
+	key = append(key, headerNumberPrefix)
+	key = append(key, hash[:]...)
+	return key
+}
+
+// blockIndexPrefix namespaces the BlockIndex's on-disk bucket so its
+// compact per-header records can be reloaded on startup without walking
+// every full header.
+const blockIndexPrefix = 'x'
+
+// flushedStateHeightPrefix stores the height of the last block whose
+// trie writes were actually flushed to LevelDB. It is written together
+// with CurrentStateTrie, only when a block's trie writes are flushed, so
+// the chain head recorded under CurrentBlockHashKey may be ahead of it by
+// up to a trie journal window. On restart, comparing the two heights
+// tells the chain store which blocks need to be replayed to catch the
+// in-memory trie back up to the chain head.
+const flushedStateHeightPrefix = 'f'
+
+// FlushedStateHeightKey builds the single key tracking the height of the
+// last flushed trie commit.
+func FlushedStateHeightKey() []byte {
+	return []byte{flushedStateHeightPrefix}
+}
+
+// BlockIndexKey builds the on-disk key for a BlockIndex node:
+// blockIndexPrefix || hash.
+func BlockIndexKey(hash common.Uint256) []byte {
+	key := make([]byte, 0, 1+len(hash))
+	key = append(key, blockIndexPrefix)
+	key = append(key, hash[:]...)
+	return key
+}
+
+// BlockIndexKeyPrefix returns the single-byte prefix shared by every
+// BlockIndex node key, for a full-bucket scan when reloading on startup.
+func BlockIndexKeyPrefix() []byte {
+	return []byte{blockIndexPrefix}
+}
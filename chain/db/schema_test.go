@@ -0,0 +1,64 @@
+package db
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nknorg/nkn/v2/common"
+)
+
+func TestEncodeDecodeBlockNumber(t *testing.T) {
+	for _, height := range []uint32{0, 1, 255, 256, 100000, 1<<32 - 1} {
+		enc := EncodeBlockNumber(height)
+		if len(enc) != 8 {
+			t.Fatalf("EncodeBlockNumber(%d): got %d bytes, want 8", height, len(enc))
+		}
+		if got := DecodeBlockNumber(enc); got != height {
+			t.Fatalf("DecodeBlockNumber(EncodeBlockNumber(%d)) = %d", height, got)
+		}
+	}
+}
+
+func TestBlockNumberOrdering(t *testing.T) {
+	low := EncodeBlockNumber(10)
+	high := EncodeBlockNumber(1000)
+	if bytes.Compare(low, high) >= 0 {
+		t.Fatalf("EncodeBlockNumber must preserve numeric ordering as byte ordering")
+	}
+}
+
+func TestHeaderSeqKeyHasHeightPrefix(t *testing.T) {
+	var hash common.Uint256
+	key := HeaderSeqKey(42, hash)
+	prefix := HeaderSeqPrefix(42)
+
+	if !bytes.HasPrefix(key, prefix) {
+		t.Fatalf("HeaderSeqKey(42, hash) = %x does not start with HeaderSeqPrefix(42) = %x", key, prefix)
+	}
+	if !bytes.HasPrefix(key, HeaderSeqKeyPrefix()) {
+		t.Fatalf("HeaderSeqKey result does not start with the shared header-seq prefix byte")
+	}
+}
+
+// BenchmarkHeaderSeqKeyEncode and BenchmarkHeaderSeqKeyDecode cover
+// building/parsing the sequential key itself. Range reads over a
+// 100k-height keyspace are benchmarked in bench_100k_test.go
+// (BenchmarkChainRead_header_100k/_full_100k) against an in-memory
+// ordered store, not real LevelDB, since no db.IStore implementation
+// exists in this tree.
+func BenchmarkHeaderSeqKeyEncode(b *testing.B) {
+	var hash common.Uint256
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = HeaderSeqKey(uint32(i), hash)
+	}
+}
+
+func BenchmarkHeaderSeqKeyDecode(b *testing.B) {
+	var hash common.Uint256
+	key := HeaderSeqKey(12345, hash)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = DecodeBlockNumber(key[1:9])
+	}
+}
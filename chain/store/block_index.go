@@ -0,0 +1,361 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/nknorg/nkn/v2/block"
+	"github.com/nknorg/nkn/v2/chain/db"
+	"github.com/nknorg/nkn/v2/common"
+	"github.com/nknorg/nkn/v2/util/config"
+	"github.com/nknorg/nkn/v2/util/log"
+)
+
+// Default depth at which a branch that no tip can reach any longer is
+// eligible for eviction from the in-memory index.
+const defaultBlockIndexEvictDepth = 2048
+
+// blockIndexNode is the compact per-header record kept by BlockIndex: just
+// enough to walk ancestry and resolve forks without materializing a full
+// block.Header.
+type blockIndexNode struct {
+	hash      common.Uint256
+	prevHash  common.Uint256
+	height    uint32
+	stateRoot common.Uint256
+	timestamp int64
+}
+
+func (n *blockIndexNode) encode() []byte {
+	buf := make([]byte, 0, len(n.prevHash)+4+len(n.stateRoot)+8)
+	buf = append(buf, n.prevHash[:]...)
+	heightBuf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(heightBuf, n.height)
+	buf = append(buf, heightBuf...)
+	buf = append(buf, n.stateRoot[:]...)
+	tsBuf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(tsBuf, uint64(n.timestamp))
+	buf = append(buf, tsBuf...)
+	return buf
+}
+
+func decodeBlockIndexNode(hash common.Uint256, data []byte) (*blockIndexNode, error) {
+	const wantLen = 32 + 4 + 32 + 8
+	if len(data) != wantLen {
+		return nil, fmt.Errorf("decodeBlockIndexNode: invalid record length %d, want %d", len(data), wantLen)
+	}
+
+	n := &blockIndexNode{hash: hash}
+	copy(n.prevHash[:], data[0:32])
+	n.height = binary.LittleEndian.Uint32(data[32:36])
+	copy(n.stateRoot[:], data[36:68])
+	n.timestamp = int64(binary.LittleEndian.Uint64(data[68:76]))
+
+	return n, nil
+}
+
+// BlockIndex maintains a compact in-memory tree of {hash, prevHash,
+// height, stateRoot, timestamp} nodes covering every known header across
+// the main chain and any live side chains, backed by a dedicated LevelDB
+// bucket so it can be reloaded on startup without walking every full
+// header. Consensus and sync code use it to reason about forks (common
+// ancestor, ancestor-at-height, chain tips) without touching full blocks.
+type BlockIndex struct {
+	st db.IStore
+
+	mu       sync.RWMutex
+	nodes    map[common.Uint256]*blockIndexNode
+	children map[common.Uint256][]common.Uint256
+	tips     map[common.Uint256]struct{}
+
+	evictDepth uint32
+}
+
+// NewBlockIndex creates an empty BlockIndex backed by st. Call Load to
+// hydrate it from a previous run.
+func NewBlockIndex(st db.IStore) *BlockIndex {
+	return &BlockIndex{
+		st:         st,
+		nodes:      make(map[common.Uint256]*blockIndexNode),
+		children:   make(map[common.Uint256][]common.Uint256),
+		tips:       make(map[common.Uint256]struct{}),
+		evictDepth: cacheSize32(config.Parameters.BlockIndexEvictDepth, defaultBlockIndexEvictDepth),
+	}
+}
+
+// EvictDepth returns how many recent heights Load/EvictBelow keep the
+// index bounded to, so callers that populate the index by some other
+// means (e.g. a schema migration) can match that same window.
+func (bi *BlockIndex) EvictDepth() uint32 {
+	return bi.evictDepth
+}
+
+// Load reloads nodes at or above currentHeight-evictDepth from the
+// on-disk bucket, reconstructing the children/tips relationships, so a
+// restart doesn't need to walk every full header to rebuild fork-choice
+// state. Nodes below that height are the same ones EvictBelow would
+// already have deleted during normal operation; skipping them bounds
+// both this scan and the in-memory index to evictDepth recent heights
+// regardless of total chain length.
+func (bi *BlockIndex) Load(currentHeight uint32) error {
+	bi.mu.Lock()
+	defer bi.mu.Unlock()
+
+	var minHeight uint32
+	if currentHeight > bi.evictDepth {
+		minHeight = currentHeight - bi.evictDepth
+	}
+
+	iter := bi.st.NewIterator(db.BlockIndexKeyPrefix())
+	defer iter.Release()
+
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) < 1+32 {
+			continue
+		}
+		var hash common.Uint256
+		copy(hash[:], key[1:1+32])
+
+		node, err := decodeBlockIndexNode(hash, iter.Value())
+		if err != nil {
+			return err
+		}
+		if node.height < minHeight {
+			continue
+		}
+
+		bi.nodes[hash] = node
+	}
+
+	for hash, node := range bi.nodes {
+		if node.height > 0 {
+			bi.children[node.prevHash] = append(bi.children[node.prevHash], hash)
+		}
+	}
+
+	for hash := range bi.nodes {
+		if len(bi.children[hash]) == 0 {
+			bi.tips[hash] = struct{}{}
+		}
+	}
+
+	log.Infof("block index loaded %d headers, %d tips", len(bi.nodes), len(bi.tips))
+
+	return nil
+}
+
+// Add inserts header into the index (a no-op if it is already present)
+// and persists it to the on-disk bucket.
+func (bi *BlockIndex) Add(header *block.Header) error {
+	hash := header.Hash()
+
+	stateRoot, err := common.Uint256ParseFromBytes(header.UnsignedHeader.StateRoot)
+	if err != nil {
+		return err
+	}
+
+	prevHash, err := common.Uint256ParseFromBytes(header.UnsignedHeader.PrevBlockHash)
+	if err != nil {
+		return err
+	}
+
+	node := &blockIndexNode{
+		hash:      hash,
+		prevHash:  prevHash,
+		height:    header.UnsignedHeader.Height,
+		stateRoot: stateRoot,
+		timestamp: header.UnsignedHeader.Timestamp,
+	}
+
+	bi.mu.Lock()
+	if _, ok := bi.nodes[hash]; ok {
+		bi.mu.Unlock()
+		return nil
+	}
+
+	bi.nodes[hash] = node
+	if node.height > 0 {
+		bi.children[prevHash] = append(bi.children[prevHash], hash)
+		delete(bi.tips, prevHash)
+	}
+	// A child may already be indexed (e.g. backfill adds ancestors after
+	// the tip), in which case this node is not a tip.
+	if len(bi.children[hash]) == 0 {
+		bi.tips[hash] = struct{}{}
+	}
+	bi.mu.Unlock()
+
+	return bi.st.Put(db.BlockIndexKey(hash), node.encode())
+}
+
+// HeightOf returns the height of hash, if known.
+func (bi *BlockIndex) HeightOf(hash common.Uint256) (uint32, bool) {
+	bi.mu.RLock()
+	defer bi.mu.RUnlock()
+
+	node, ok := bi.nodes[hash]
+	if !ok {
+		return 0, false
+	}
+	return node.height, true
+}
+
+// Tips returns the hash of every block with no known child, i.e. the
+// head of every live chain the index knows about.
+func (bi *BlockIndex) Tips() []common.Uint256 {
+	bi.mu.RLock()
+	defer bi.mu.RUnlock()
+
+	tips := make([]common.Uint256, 0, len(bi.tips))
+	for hash := range bi.tips {
+		tips = append(tips, hash)
+	}
+	return tips
+}
+
+// Ancestor returns the hash of hash's ancestor at the given height, by
+// walking prevHash pointers. It returns false if hash's ancestry does
+// not reach back that far in the index.
+func (bi *BlockIndex) Ancestor(hash common.Uint256, height uint32) (common.Uint256, bool) {
+	bi.mu.RLock()
+	defer bi.mu.RUnlock()
+
+	node, ok := bi.nodes[hash]
+	if !ok {
+		return common.EmptyUint256, false
+	}
+
+	for node.height > height {
+		parent, ok := bi.nodes[node.prevHash]
+		if !ok {
+			return common.EmptyUint256, false
+		}
+		node = parent
+	}
+
+	if node.height != height {
+		return common.EmptyUint256, false
+	}
+
+	return node.hash, true
+}
+
+// CommonAncestor returns the most recent block reachable from both a and
+// b, by walking the higher of the two back to the other's height and
+// then both branches back together.
+func (bi *BlockIndex) CommonAncestor(a, b common.Uint256) (common.Uint256, bool) {
+	bi.mu.RLock()
+	defer bi.mu.RUnlock()
+
+	nodeA, ok := bi.nodes[a]
+	if !ok {
+		return common.EmptyUint256, false
+	}
+	nodeB, ok := bi.nodes[b]
+	if !ok {
+		return common.EmptyUint256, false
+	}
+
+	for nodeA.height > nodeB.height {
+		nodeA, ok = bi.nodes[nodeA.prevHash]
+		if !ok {
+			return common.EmptyUint256, false
+		}
+	}
+	for nodeB.height > nodeA.height {
+		nodeB, ok = bi.nodes[nodeB.prevHash]
+		if !ok {
+			return common.EmptyUint256, false
+		}
+	}
+
+	for nodeA.hash != nodeB.hash {
+		nodeA, ok = bi.nodes[nodeA.prevHash]
+		if !ok {
+			return common.EmptyUint256, false
+		}
+		nodeB, ok = bi.nodes[nodeB.prevHash]
+		if !ok {
+			return common.EmptyUint256, false
+		}
+	}
+
+	return nodeA.hash, true
+}
+
+// reachableFromTips walks prevHash pointers back from every tip at or
+// above cutoff and returns the set of hashes reached, stopping each walk
+// once it reaches a node at or below cutoff. Tips below cutoff are dead
+// branches and are not walked. It is a pure function of the in-memory
+// index so it can be tested without a backing database.
+func reachableFromTips(nodes map[common.Uint256]*blockIndexNode, tips map[common.Uint256]struct{}, cutoff uint32) map[common.Uint256]struct{} {
+	reachable := make(map[common.Uint256]struct{}, len(nodes))
+	for tip := range tips {
+		if node, ok := nodes[tip]; !ok || node.height < cutoff {
+			continue
+		}
+		hash := tip
+		for {
+			node, ok := nodes[hash]
+			if !ok {
+				break
+			}
+			if _, seen := reachable[hash]; seen {
+				break
+			}
+			reachable[hash] = struct{}{}
+			if node.height <= cutoff {
+				break
+			}
+			hash = node.prevHash
+		}
+	}
+	return reachable
+}
+
+// EvictBelow drops branches that end below height and are not reachable
+// from any current tip within evictDepth, freeing memory held by dead
+// side chains, and deletes their on-disk records so the BlockIndexKey
+// bucket and the next Load() stay bounded to evictDepth recent heights
+// instead of growing with total chain length.
+func (bi *BlockIndex) EvictBelow(height uint32) error {
+	bi.mu.Lock()
+	defer bi.mu.Unlock()
+
+	if height < bi.evictDepth {
+		return nil
+	}
+	cutoff := height - bi.evictDepth
+
+	reachable := reachableFromTips(bi.nodes, bi.tips, cutoff)
+
+	var evicted []common.Uint256
+	for hash, node := range bi.nodes {
+		if node.height < cutoff {
+			if _, ok := reachable[hash]; !ok {
+				evicted = append(evicted, hash)
+			}
+		}
+	}
+
+	if len(evicted) == 0 {
+		return nil
+	}
+
+	if err := bi.st.NewBatch(); err != nil {
+		return err
+	}
+	for _, hash := range evicted {
+		delete(bi.nodes, hash)
+		delete(bi.children, hash)
+		delete(bi.tips, hash)
+
+		if err := bi.st.BatchDelete(db.BlockIndexKey(hash)); err != nil {
+			return err
+		}
+	}
+
+	return bi.st.BatchCommit()
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/nknorg/nkn/v2/common"
+)
+
+func hashAt(b byte) common.Uint256 {
+	var h common.Uint256
+	h[0] = b
+	return h
+}
+
+func newTestBlockIndex(nodes ...*blockIndexNode) *BlockIndex {
+	bi := &BlockIndex{
+		nodes:    make(map[common.Uint256]*blockIndexNode),
+		children: make(map[common.Uint256][]common.Uint256),
+		tips:     make(map[common.Uint256]struct{}),
+	}
+	for _, n := range nodes {
+		bi.nodes[n.hash] = n
+		if n.height > 0 {
+			bi.children[n.prevHash] = append(bi.children[n.prevHash], n.hash)
+		}
+	}
+	for hash := range bi.nodes {
+		if len(bi.children[hash]) == 0 {
+			bi.tips[hash] = struct{}{}
+		}
+	}
+	return bi
+}
+
+func TestBlockIndexAncestorAndCommonAncestor(t *testing.T) {
+	// genesis(0) -> a1(1) -> a2(2) -> aTip(3)
+	//                  \-> b2(2) -> bTip(3)
+	genesis := &blockIndexNode{hash: hashAt(0), height: 0}
+	a1 := &blockIndexNode{hash: hashAt(1), prevHash: genesis.hash, height: 1}
+	a2 := &blockIndexNode{hash: hashAt(2), prevHash: a1.hash, height: 2}
+	aTip := &blockIndexNode{hash: hashAt(3), prevHash: a2.hash, height: 3}
+	b2 := &blockIndexNode{hash: hashAt(4), prevHash: a1.hash, height: 2}
+	bTip := &blockIndexNode{hash: hashAt(5), prevHash: b2.hash, height: 3}
+
+	bi := newTestBlockIndex(genesis, a1, a2, aTip, b2, bTip)
+
+	ancestor, ok := bi.Ancestor(aTip.hash, 1)
+	if !ok || ancestor != a1.hash {
+		t.Fatalf("Ancestor(aTip, 1) = %v, %v, want %v, true", ancestor, ok, a1.hash)
+	}
+
+	ancestorAB, ok := bi.CommonAncestor(aTip.hash, bTip.hash)
+	if !ok || ancestorAB != a1.hash {
+		t.Fatalf("CommonAncestor(aTip, bTip) = %v, %v, want %v, true", ancestorAB, ok, a1.hash)
+	}
+
+	if _, ok := bi.Ancestor(aTip.hash, 10); ok {
+		t.Fatalf("Ancestor at a height beyond the index should not be found")
+	}
+}
+
+func TestBlockIndexEvictDepth(t *testing.T) {
+	bi := &BlockIndex{evictDepth: 2048}
+	if got := bi.EvictDepth(); got != 2048 {
+		t.Fatalf("EvictDepth() = %d, want 2048", got)
+	}
+}
+
+func TestReachableFromTips(t *testing.T) {
+	// main(0..4), dead side branch forking off main at height 1.
+	main0 := &blockIndexNode{hash: hashAt(0), height: 0}
+	main1 := &blockIndexNode{hash: hashAt(1), prevHash: main0.hash, height: 1}
+	main2 := &blockIndexNode{hash: hashAt(2), prevHash: main1.hash, height: 2}
+	main3 := &blockIndexNode{hash: hashAt(3), prevHash: main2.hash, height: 3}
+	dead := &blockIndexNode{hash: hashAt(9), prevHash: main1.hash, height: 2}
+
+	nodes := map[common.Uint256]*blockIndexNode{
+		main0.hash: main0, main1.hash: main1, main2.hash: main2, main3.hash: main3,
+		dead.hash: dead,
+	}
+	tips := map[common.Uint256]struct{}{main3.hash: {}}
+
+	reachable := reachableFromTips(nodes, tips, 0)
+
+	for _, want := range []common.Uint256{main0.hash, main1.hash, main2.hash, main3.hash} {
+		if _, ok := reachable[want]; !ok {
+			t.Fatalf("expected %v reachable from the only tip", want)
+		}
+	}
+	if _, ok := reachable[dead.hash]; ok {
+		t.Fatalf("dead branch should not be reachable from the main tip")
+	}
+}
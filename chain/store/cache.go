@@ -0,0 +1,207 @@
+package store
+
+import (
+	"sync/atomic"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/nknorg/nkn/v2/block"
+	"github.com/nknorg/nkn/v2/common"
+	"github.com/nknorg/nkn/v2/transaction"
+	"github.com/nknorg/nkn/v2/util/config"
+)
+
+// Default cache sizes, used when config.Parameters leaves the
+// corresponding field unset (zero).
+const (
+	defaultHeaderCacheSize       = 512
+	defaultBodyCacheSize         = 256
+	defaultBlockCacheSize        = 256
+	defaultTxCacheSize           = 1024
+	defaultHeightToHashCacheSize = 2048
+)
+
+// blockBody is the trimmed body kept in bodyCache: just enough to
+// re-hydrate a block's transaction list from the tx cache/DB without
+// storing the full block a second time.
+type blockBody struct {
+	txHashes []common.Uint256
+}
+
+// cachedTx pairs a transaction with the height it was mined at, mirroring
+// the value layout stored under db.TransactionKey.
+type cachedTx struct {
+	txn    *transaction.Transaction
+	height uint32
+}
+
+// cacheStat is a simple hit/miss counter for one cache tier.
+type cacheStat struct {
+	hits   uint64
+	misses uint64
+}
+
+func (s *cacheStat) hit()  { atomic.AddUint64(&s.hits, 1) }
+func (s *cacheStat) miss() { atomic.AddUint64(&s.misses, 1) }
+
+// CacheStat is the read-only snapshot returned by ChainStore.CacheStats.
+type CacheStat struct {
+	Hits   uint64
+	Misses uint64
+}
+
+func (s *cacheStat) snapshot() CacheStat {
+	return CacheStat{
+		Hits:   atomic.LoadUint64(&s.hits),
+		Misses: atomic.LoadUint64(&s.misses),
+	}
+}
+
+// chainCache is the tiered LRU cache sitting in front of the LevelDB chain
+// store, split by the shape of what it holds so a burst of reads against
+// one tier (e.g. headers during sync) doesn't evict another (e.g. hot
+// recent blocks served over RPC).
+type chainCache struct {
+	headers      *lru.Cache // common.Uint256 -> *block.Header
+	bodies       *lru.Cache // common.Uint256 -> *blockBody
+	blocks       *lru.Cache // common.Uint256 -> *block.Block
+	txs          *lru.Cache // common.Uint256 -> *cachedTx
+	heightToHash *lru.Cache // uint32 -> common.Uint256
+
+	headerStat cacheStat
+	bodyStat   cacheStat
+	blockStat  cacheStat
+	txStat     cacheStat
+	heightStat cacheStat
+}
+
+func cacheSize(configured, def int) int {
+	if configured > 0 {
+		return configured
+	}
+	return def
+}
+
+func newChainCache() (*chainCache, error) {
+	headers, err := lru.New(cacheSize(config.Parameters.HeaderCacheSize, defaultHeaderCacheSize))
+	if err != nil {
+		return nil, err
+	}
+
+	bodies, err := lru.New(cacheSize(config.Parameters.BodyCacheSize, defaultBodyCacheSize))
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := lru.New(cacheSize(config.Parameters.BlockCacheSize, defaultBlockCacheSize))
+	if err != nil {
+		return nil, err
+	}
+
+	txs, err := lru.New(cacheSize(config.Parameters.TxCacheSize, defaultTxCacheSize))
+	if err != nil {
+		return nil, err
+	}
+
+	heightToHash, err := lru.New(cacheSize(config.Parameters.HeightToHashCacheSize, defaultHeightToHashCacheSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return &chainCache{
+		headers:      headers,
+		bodies:       bodies,
+		blocks:       blocks,
+		txs:          txs,
+		heightToHash: heightToHash,
+	}, nil
+}
+
+func (c *chainCache) getHeader(hash common.Uint256) (*block.Header, bool) {
+	v, ok := c.headers.Get(hash)
+	if !ok {
+		c.headerStat.miss()
+		return nil, false
+	}
+	c.headerStat.hit()
+	return v.(*block.Header), true
+}
+
+func (c *chainCache) putHeader(hash common.Uint256, h *block.Header) {
+	c.headers.Add(hash, h)
+}
+
+func (c *chainCache) getBody(hash common.Uint256) (*blockBody, bool) {
+	v, ok := c.bodies.Get(hash)
+	if !ok {
+		c.bodyStat.miss()
+		return nil, false
+	}
+	c.bodyStat.hit()
+	return v.(*blockBody), true
+}
+
+func (c *chainCache) putBody(hash common.Uint256, b *blockBody) {
+	c.bodies.Add(hash, b)
+}
+
+func (c *chainCache) getBlock(hash common.Uint256) (*block.Block, bool) {
+	v, ok := c.blocks.Get(hash)
+	if !ok {
+		c.blockStat.miss()
+		return nil, false
+	}
+	c.blockStat.hit()
+	return v.(*block.Block), true
+}
+
+func (c *chainCache) putBlock(hash common.Uint256, b *block.Block) {
+	c.blocks.Add(hash, b)
+}
+
+func (c *chainCache) getTx(hash common.Uint256) (*cachedTx, bool) {
+	v, ok := c.txs.Get(hash)
+	if !ok {
+		c.txStat.miss()
+		return nil, false
+	}
+	c.txStat.hit()
+	return v.(*cachedTx), true
+}
+
+func (c *chainCache) putTx(hash common.Uint256, tx *cachedTx) {
+	c.txs.Add(hash, tx)
+}
+
+func (c *chainCache) getHeightToHash(height uint32) (common.Uint256, bool) {
+	v, ok := c.heightToHash.Get(height)
+	if !ok {
+		c.heightStat.miss()
+		return common.EmptyUint256, false
+	}
+	c.heightStat.hit()
+	return v.(common.Uint256), true
+}
+
+func (c *chainCache) putHeightToHash(height uint32, hash common.Uint256) {
+	c.heightToHash.Add(height, hash)
+}
+
+// removeHeight drops a cached height->hash mapping so stale data can't be
+// served. ChainStore itself does not call it; persist overwrites the
+// mapping when a new block is saved at that height.
+func (c *chainCache) removeHeight(height uint32) {
+	c.heightToHash.Remove(height)
+}
+
+// CacheStats reports hit/miss counters for each cache tier, keyed by tier
+// name, for monitoring LevelDB read amplification under RPC/sync load.
+func (cs *ChainStore) CacheStats() map[string]CacheStat {
+	return map[string]CacheStat{
+		"header":       cs.cache.headerStat.snapshot(),
+		"body":         cs.cache.bodyStat.snapshot(),
+		"block":        cs.cache.blockStat.snapshot(),
+		"tx":           cs.cache.txStat.snapshot(),
+		"heightToHash": cs.cache.heightStat.snapshot(),
+	}
+}
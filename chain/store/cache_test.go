@@ -0,0 +1,146 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/nknorg/nkn/v2/block"
+	"github.com/nknorg/nkn/v2/common"
+)
+
+func TestChainCacheHeaderRoundTrip(t *testing.T) {
+	c, err := newChainCache()
+	if err != nil {
+		t.Fatalf("newChainCache: %v", err)
+	}
+
+	var hash common.Uint256
+	hash[0] = 1
+
+	if _, ok := c.getHeader(hash); ok {
+		t.Fatalf("expected a miss on an empty cache")
+	}
+	if got := c.headerStat.snapshot(); got.Misses != 1 {
+		t.Fatalf("headerStat.Misses = %d, want 1", got.Misses)
+	}
+
+	h := &block.Header{}
+	c.putHeader(hash, h)
+
+	got, ok := c.getHeader(hash)
+	if !ok || got != h {
+		t.Fatalf("getHeader after putHeader = %v, %v, want the same pointer, true", got, ok)
+	}
+	if stat := c.headerStat.snapshot(); stat.Hits != 1 || stat.Misses != 1 {
+		t.Fatalf("headerStat = %+v, want 1 hit and 1 miss", stat)
+	}
+}
+
+func TestChainCacheBodyRoundTrip(t *testing.T) {
+	c, err := newChainCache()
+	if err != nil {
+		t.Fatalf("newChainCache: %v", err)
+	}
+
+	var hash common.Uint256
+	hash[0] = 2
+
+	if _, ok := c.getBody(hash); ok {
+		t.Fatalf("expected a miss on an empty cache")
+	}
+	if got := c.bodyStat.snapshot(); got.Misses != 1 {
+		t.Fatalf("bodyStat.Misses = %d, want 1", got.Misses)
+	}
+
+	body := &blockBody{txHashes: []common.Uint256{hash}}
+	c.putBody(hash, body)
+
+	got, ok := c.getBody(hash)
+	if !ok || got != body {
+		t.Fatalf("getBody after putBody = %v, %v, want the same pointer, true", got, ok)
+	}
+	if stat := c.bodyStat.snapshot(); stat.Hits != 1 || stat.Misses != 1 {
+		t.Fatalf("bodyStat = %+v, want 1 hit and 1 miss", stat)
+	}
+}
+
+func TestChainCacheBlockRoundTrip(t *testing.T) {
+	c, err := newChainCache()
+	if err != nil {
+		t.Fatalf("newChainCache: %v", err)
+	}
+
+	var hash common.Uint256
+	hash[0] = 3
+
+	if _, ok := c.getBlock(hash); ok {
+		t.Fatalf("expected a miss on an empty cache")
+	}
+	if got := c.blockStat.snapshot(); got.Misses != 1 {
+		t.Fatalf("blockStat.Misses = %d, want 1", got.Misses)
+	}
+
+	b := &block.Block{}
+	c.putBlock(hash, b)
+
+	got, ok := c.getBlock(hash)
+	if !ok || got != b {
+		t.Fatalf("getBlock after putBlock = %v, %v, want the same pointer, true", got, ok)
+	}
+	if stat := c.blockStat.snapshot(); stat.Hits != 1 || stat.Misses != 1 {
+		t.Fatalf("blockStat = %+v, want 1 hit and 1 miss", stat)
+	}
+}
+
+func TestChainCacheTxRoundTrip(t *testing.T) {
+	c, err := newChainCache()
+	if err != nil {
+		t.Fatalf("newChainCache: %v", err)
+	}
+
+	var hash common.Uint256
+	hash[0] = 4
+
+	if _, ok := c.getTx(hash); ok {
+		t.Fatalf("expected a miss on an empty cache")
+	}
+	if got := c.txStat.snapshot(); got.Misses != 1 {
+		t.Fatalf("txStat.Misses = %d, want 1", got.Misses)
+	}
+
+	tx := &cachedTx{height: 100}
+	c.putTx(hash, tx)
+
+	got, ok := c.getTx(hash)
+	if !ok || got != tx {
+		t.Fatalf("getTx after putTx = %v, %v, want the same pointer, true", got, ok)
+	}
+	if stat := c.txStat.snapshot(); stat.Hits != 1 || stat.Misses != 1 {
+		t.Fatalf("txStat = %+v, want 1 hit and 1 miss", stat)
+	}
+}
+
+func TestChainCacheHeightToHash(t *testing.T) {
+	c, err := newChainCache()
+	if err != nil {
+		t.Fatalf("newChainCache: %v", err)
+	}
+
+	var hash common.Uint256
+	hash[0] = 7
+
+	if _, ok := c.getHeightToHash(100); ok {
+		t.Fatalf("expected a miss on an empty cache")
+	}
+
+	c.putHeightToHash(100, hash)
+
+	got, ok := c.getHeightToHash(100)
+	if !ok || got != hash {
+		t.Fatalf("getHeightToHash(100) = %v, %v, want %v, true", got, ok, hash)
+	}
+
+	c.removeHeight(100)
+	if _, ok := c.getHeightToHash(100); ok {
+		t.Fatalf("expected a miss after removeHeight")
+	}
+}
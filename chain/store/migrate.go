@@ -0,0 +1,100 @@
+package store
+
+import (
+	"github.com/nknorg/nkn/v2/chain/db"
+	"github.com/nknorg/nkn/v2/util/log"
+)
+
+// schemaSeqVersion is the DBVersion at which headers gained entries in
+// the sequential, block-number-prefixed schema in chain/db/schema.go,
+// plus a hash->height lookup. Bodies and transaction lookups are not part
+// of that schema and stay keyed by hash only. A node on exactly
+// the previous version is migrated in place; anything older falls back
+// to the existing full ResetDB path, since earlier layouts changed more
+// than just the key scheme.
+const schemaSeqVersion = 0x02
+
+// migrateToSequentialSchema walks the existing height range and rewrites
+// header entries under the sequential-key schema so height-based bulk
+// reads (GetStateRoots, header sync) can stream through LevelDB with one
+// iterator instead of one random-access seek per height. It is invoked
+// once from InitLedgerStoreWithGenesisBlock when the on-disk version is
+// exactly schemaSeqVersion-1.
+func (cs *ChainStore) migrateToSequentialSchema(currentHeight uint32) error {
+	log.Infof("migrating chain store to sequential key schema (%d headers)", currentHeight+1)
+
+	const flushEvery = 2000
+
+	if err := cs.st.NewBatch(); err != nil {
+		return err
+	}
+
+	for height := uint32(0); height <= currentHeight; height++ {
+		hash, err := cs.GetBlockHash(height)
+		if err != nil {
+			return err
+		}
+
+		headerData, err := cs.st.Get(db.HeaderKey(hash))
+		if err != nil {
+			return err
+		}
+
+		if err := cs.st.BatchPut(db.HeaderSeqKey(height, hash), headerData); err != nil {
+			return err
+		}
+
+		if err := cs.st.BatchPut(db.HeaderNumberKey(hash), db.EncodeBlockNumber(height)); err != nil {
+			return err
+		}
+
+		if height > 0 && height%flushEvery == 0 {
+			if err := cs.st.BatchCommit(); err != nil {
+				return err
+			}
+			if err := cs.st.NewBatch(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := cs.st.BatchPut(db.VersionKey(), []byte{schemaSeqVersion}); err != nil {
+		return err
+	}
+
+	if err := cs.st.BatchCommit(); err != nil {
+		return err
+	}
+
+	log.Infof("sequential key schema migration complete at height %d", currentHeight)
+
+	return nil
+}
+
+// backfillBlockIndex populates cs.blockIndex with the same evictDepth-
+// bounded window of recent heights that blockIndex.Load() would restore
+// on a plain restart. Migration builds the index from scratch in memory
+// rather than reloading it from the on-disk bucket, so without this call
+// the index would only contain the single tip header added by the
+// caller, leaving Ancestor/CommonAncestor blind to anything below it
+// until evictDepth more blocks are appended.
+func (cs *ChainStore) backfillBlockIndex(currentHeight uint32) error {
+	var fromHeight uint32
+	if depth := cs.blockIndex.EvictDepth(); currentHeight > depth {
+		fromHeight = currentHeight - depth
+	}
+
+	log.Infof("backfilling block index for heights %d-%d after schema migration", fromHeight, currentHeight)
+
+	for height := fromHeight; height < currentHeight; height++ {
+		header, err := cs.GetHeaderByHeight(height)
+		if err != nil {
+			return err
+		}
+		if err := cs.blockIndex.Add(header); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nknorg/nkn/v2/common"
+	"github.com/nknorg/nkn/v2/common/serialization"
+	"github.com/nknorg/nkn/v2/util/config"
+)
+
+// This file provides only the wire format for a state snapshot: a
+// header (format version, height, state root, chain ID), length-prefixed
+// key/value entries grouped by trie subtree, and a one-byte end marker.
+// No manifest hash is written after the end marker or checked on read.
+// It does NOT provide a working fast-sync bootstrap feature.
+//
+// Streaming a real snapshot requires walking StateDB's trie at a fixed
+// historical root and rebuilding it bottom-up on import, and StateDB's
+// trie-walking internals aren't visible anywhere in this tree (no commit
+// in this series touches it), so ExportStateSnapshot/ImportStateSnapshot
+// are deliberately not implemented here rather than shipped as two
+// methods that call into an interface no concrete type satisfies. The
+// request this codec was built for remains open pending that StateDB
+// integration; only the format itself is settled and tested
+// (snapshot_test.go).
+
+// snapshotFormatVersion versions the on-disk/wire layout, independent of
+// config.DBVersion, so older clients can at least recognize (and refuse)
+// a newer format.
+const snapshotFormatVersion = 1
+
+// Subtree tags used in the chunked snapshot format, one per logical
+// piece of state StateDB maintains.
+const (
+	SnapshotSubtreeAccount byte = iota
+	SnapshotSubtreeName
+	SnapshotSubtreeNanoPay
+	SnapshotSubtreeID
+)
+
+func writeSnapshotHeader(w io.Writer, height uint32, stateRoot common.Uint256) error {
+	if err := serialization.WriteUint32(w, snapshotFormatVersion); err != nil {
+		return err
+	}
+	if err := serialization.WriteUint32(w, height); err != nil {
+		return err
+	}
+	if _, err := stateRoot.Serialize(w); err != nil {
+		return err
+	}
+	return serialization.WriteUint32(w, config.ChainID)
+}
+
+// readSnapshotHeader reads back what writeSnapshotHeader wrote and
+// validates the format version and chain ID before the caller commits to
+// reading the (potentially large) body.
+func readSnapshotHeader(r io.Reader) (height uint32, stateRoot common.Uint256, err error) {
+	version, err := serialization.ReadUint32(r)
+	if err != nil {
+		return 0, common.EmptyUint256, err
+	}
+	if version != snapshotFormatVersion {
+		return 0, common.EmptyUint256, fmt.Errorf("readSnapshotHeader: unsupported snapshot format version %d", version)
+	}
+
+	height, err = serialization.ReadUint32(r)
+	if err != nil {
+		return 0, common.EmptyUint256, err
+	}
+
+	if err := stateRoot.Deserialize(r); err != nil {
+		return 0, common.EmptyUint256, err
+	}
+
+	chainID, err := serialization.ReadUint32(r)
+	if err != nil {
+		return 0, common.EmptyUint256, err
+	}
+	if chainID != config.ChainID {
+		return 0, common.EmptyUint256, fmt.Errorf("readSnapshotHeader: snapshot is for chain ID %d, this node is on %d", chainID, config.ChainID)
+	}
+
+	return height, stateRoot, nil
+}
+
+// snapshotEntryMarker precedes every key/value tuple; snapshotEndMarker
+// replaces it once to terminate the entry stream, so the reader doesn't
+// need a separate entry count up front.
+const (
+	snapshotEntryMarker byte = 0x01
+	snapshotEndMarker   byte = 0xff
+)
+
+func writeSnapshotEntry(w io.Writer, subtree byte, key, value []byte) error {
+	if _, err := w.Write([]byte{snapshotEntryMarker, subtree}); err != nil {
+		return err
+	}
+	if err := serialization.WriteVarBytes(w, key); err != nil {
+		return err
+	}
+	return serialization.WriteVarBytes(w, value)
+}
+
+func writeSnapshotTrailer(w io.Writer) error {
+	_, err := w.Write([]byte{snapshotEndMarker})
+	return err
+}
+
+func readSnapshotEntry(r io.Reader) (subtree byte, key, value []byte, end bool, err error) {
+	marker := make([]byte, 1)
+	if _, err := io.ReadFull(r, marker); err != nil {
+		return 0, nil, nil, false, fmt.Errorf("readSnapshotEntry: %v", err)
+	}
+
+	if marker[0] == snapshotEndMarker {
+		return 0, nil, nil, true, nil
+	}
+	if marker[0] != snapshotEntryMarker {
+		return 0, nil, nil, false, fmt.Errorf("readSnapshotEntry: unexpected marker byte 0x%x", marker[0])
+	}
+
+	subtreeBuf := make([]byte, 1)
+	if _, err := io.ReadFull(r, subtreeBuf); err != nil {
+		return 0, nil, nil, false, err
+	}
+
+	key, err = serialization.ReadVarBytes(r)
+	if err != nil {
+		return 0, nil, nil, false, err
+	}
+
+	value, err = serialization.ReadVarBytes(r)
+	if err != nil {
+		return 0, nil, nil, false, err
+	}
+
+	return subtreeBuf[0], key, value, false, nil
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nknorg/nkn/v2/common"
+	"github.com/nknorg/nkn/v2/util/config"
+)
+
+// TestSnapshotHeaderRoundTrip exercises writeSnapshotHeader/
+// readSnapshotHeader directly; this tree has no ChainStore method that
+// exports or imports a whole state snapshot, so only the wire format
+// itself is tested.
+func TestSnapshotHeaderRoundTrip(t *testing.T) {
+	config.ChainID = 7
+
+	var stateRoot common.Uint256
+	stateRoot[0] = 0xab
+
+	buf := bytes.NewBuffer(nil)
+	if err := writeSnapshotHeader(buf, 42, stateRoot); err != nil {
+		t.Fatalf("writeSnapshotHeader: %v", err)
+	}
+
+	height, gotRoot, err := readSnapshotHeader(buf)
+	if err != nil {
+		t.Fatalf("readSnapshotHeader: %v", err)
+	}
+	if height != 42 {
+		t.Fatalf("height = %d, want 42", height)
+	}
+	if gotRoot.CompareTo(stateRoot) != 0 {
+		t.Fatalf("stateRoot = %v, want %v", gotRoot.ToHexString(), stateRoot.ToHexString())
+	}
+}
+
+func TestSnapshotHeaderRejectsWrongChainID(t *testing.T) {
+	config.ChainID = 1
+
+	var stateRoot common.Uint256
+	buf := bytes.NewBuffer(nil)
+	if err := writeSnapshotHeader(buf, 1, stateRoot); err != nil {
+		t.Fatalf("writeSnapshotHeader: %v", err)
+	}
+
+	config.ChainID = 2
+	if _, _, err := readSnapshotHeader(buf); err == nil {
+		t.Fatalf("expected readSnapshotHeader to reject a mismatched chain ID")
+	}
+}
+
+// TestSnapshotEntryRoundTrip exercises writeSnapshotEntry/
+// writeSnapshotTrailer/readSnapshotEntry across a multi-entry stream,
+// the per-entry half of the snapshot wire format.
+func TestSnapshotEntryRoundTrip(t *testing.T) {
+	type entry struct {
+		subtree    byte
+		key, value []byte
+	}
+	entries := []entry{
+		{subtree: SnapshotSubtreeAccount, key: []byte("acct-1"), value: []byte("balance-1")},
+		{subtree: SnapshotSubtreeName, key: []byte("name-1"), value: []byte("owner-1")},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	for _, e := range entries {
+		if err := writeSnapshotEntry(buf, e.subtree, e.key, e.value); err != nil {
+			t.Fatalf("writeSnapshotEntry: %v", err)
+		}
+	}
+	if err := writeSnapshotTrailer(buf); err != nil {
+		t.Fatalf("writeSnapshotTrailer: %v", err)
+	}
+
+	for i, want := range entries {
+		subtree, key, value, end, err := readSnapshotEntry(buf)
+		if err != nil {
+			t.Fatalf("readSnapshotEntry(%d): %v", i, err)
+		}
+		if end {
+			t.Fatalf("readSnapshotEntry(%d): unexpected end marker", i)
+		}
+		if subtree != want.subtree || !bytes.Equal(key, want.key) || !bytes.Equal(value, want.value) {
+			t.Fatalf("readSnapshotEntry(%d) = (%d, %q, %q), want (%d, %q, %q)",
+				i, subtree, key, value, want.subtree, want.key, want.value)
+		}
+	}
+
+	_, _, _, end, err := readSnapshotEntry(buf)
+	if err != nil {
+		t.Fatalf("readSnapshotEntry(trailer): %v", err)
+	}
+	if !end {
+		t.Fatalf("expected the trailer to report end=true")
+	}
+}
@@ -24,8 +24,10 @@ type ChainStore struct {
 	st db.IStore
 
 	mu          sync.RWMutex
-	blockCache  map[common.Uint256]*block.Block
+	cache       *chainCache
 	headerCache *HeaderCache
+	blockIndex  *BlockIndex
+	trieJournal *trieJournal
 	States      *StateDB
 
 	currentBlockHash   common.Uint256
@@ -38,10 +40,17 @@ func NewLedgerStore() (*ChainStore, error) {
 		return nil, err
 	}
 
+	cache, err := newChainCache()
+	if err != nil {
+		return nil, err
+	}
+
 	chain := &ChainStore{
 		st:                 st,
-		blockCache:         map[common.Uint256]*block.Block{},
+		cache:              cache,
 		headerCache:        NewHeaderCache(),
+		blockIndex:         NewBlockIndex(st),
+		trieJournal:        newTrieJournal(),
 		currentBlockHeight: 0,
 		currentBlockHash:   common.EmptyUint256,
 	}
@@ -49,7 +58,17 @@ func NewLedgerStore() (*ChainStore, error) {
 	return chain, nil
 }
 
+// Close flushes any trie writes the trie journal deferred, so the chain
+// store doesn't rely on recoverPendingStateTrie replaying them from the
+// block store on every graceful restart, then closes the database.
 func (cs *ChainStore) Close() {
+	if cs.trieJournal.pending() {
+		flushedHeight := cs.getFlushedStateHeight(cs.currentBlockHeight)
+		if err := cs.recoverPendingStateTrie(flushedHeight, cs.currentBlockHeight); err != nil {
+			log.Errorf("closing chain store: flushing pending trie writes: %v; they will be replayed from the last flushed root on next start", err)
+		}
+	}
+
 	cs.st.Close()
 }
 
@@ -72,6 +91,73 @@ func (cs *ChainStore) InitLedgerStoreWithGenesisBlock(genesisBlock *block.Block)
 
 	log.Info("database Version:", config.DBVersion)
 
+	if version[0] == schemaSeqVersion-1 && config.DBVersion == schemaSeqVersion {
+		currentHash, currentHeight, err := cs.getCurrentBlockHashFromDB()
+		if err != nil {
+			return 0, fmt.Errorf("InitLedgerStoreWithGenesisBlock, reading current block for migration: %v", err)
+		}
+
+		if err := cs.migrateToSequentialSchema(currentHeight); err != nil {
+			return 0, fmt.Errorf("InitLedgerStoreWithGenesisBlock, migrateToSequentialSchema error: %v", err)
+		}
+
+		cs.currentBlockHash = currentHash
+		cs.currentBlockHeight = currentHeight
+
+		currentHeader, err := cs.GetHeader(cs.currentBlockHash)
+		if err != nil {
+			return 0, err
+		}
+		cs.headerCache.AddHeaderToCache(currentHeader)
+
+		if err := cs.blockIndex.Add(currentHeader); err != nil {
+			return 0, err
+		}
+
+		// A plain restart rebuilds the index down to evictDepth recent
+		// heights via blockIndex.Load(); migration instead builds the
+		// index from scratch in memory, so without this it would only
+		// ever contain the single tip node added above. Backfill the same
+		// evictDepth-bounded window here so Ancestor/CommonAncestor work
+		// immediately after an upgrade instead of only once evictDepth
+		// more blocks accumulate.
+		if err := cs.backfillBlockIndex(currentHeight); err != nil {
+			return 0, fmt.Errorf("InitLedgerStoreWithGenesisBlock, backfillBlockIndex error: %v", err)
+		}
+
+		root, err := cs.GetCurrentBlockStateRoot()
+		if err != nil {
+			return 0, nil
+		}
+
+		cs.States, err = NewStateDB(root, cs)
+		if err != nil {
+			return 0, err
+		}
+
+		flushedHeight := cs.getFlushedStateHeight(cs.currentBlockHeight)
+		if flushedHeight < cs.currentBlockHeight {
+			log.Warnf("chain store was closed with trie writes deferred for heights %d-%d, replaying from block store", flushedHeight+1, cs.currentBlockHeight)
+			if err := cs.recoverPendingStateTrie(flushedHeight, cs.currentBlockHeight); err != nil {
+				return 0, fmt.Errorf("InitLedgerStoreWithGenesisBlock, recoverPendingStateTrie error: %v", err)
+			}
+		}
+
+		switch config.Parameters.StatePruningMode {
+		case "lowmem":
+			err = cs.PruneStatesLowMemory(true)
+		case "none":
+			err = nil
+		default:
+			err = fmt.Errorf("unknown state pruning mode %v", config.Parameters.StatePruningMode)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		return cs.currentBlockHeight, nil
+	}
+
 	if version[0] != config.DBVersion {
 		if err := cs.ResetDB(); err != nil {
 			return 0, fmt.Errorf("InitLedgerStoreWithGenesisBlock, ResetDB error: %v", err)
@@ -83,7 +169,7 @@ func (cs *ChainStore) InitLedgerStoreWithGenesisBlock(genesisBlock *block.Block)
 			return 0, err
 		}
 
-		if err := cs.persist(genesisBlock); err != nil {
+		if _, err := cs.persist(genesisBlock); err != nil {
 			return 0, err
 		}
 
@@ -92,6 +178,9 @@ func (cs *ChainStore) InitLedgerStoreWithGenesisBlock(genesisBlock *block.Block)
 		}
 
 		cs.headerCache.AddHeaderToCache(genesisBlock.Header)
+		if err := cs.blockIndex.Add(genesisBlock.Header); err != nil {
+			return 0, err
+		}
 		cs.currentBlockHash = genesisBlock.Hash()
 		cs.currentBlockHeight = 0
 
@@ -113,6 +202,15 @@ func (cs *ChainStore) InitLedgerStoreWithGenesisBlock(genesisBlock *block.Block)
 
 	cs.headerCache.AddHeaderToCache(currentHeader)
 
+	if err := cs.blockIndex.Load(cs.currentBlockHeight); err != nil {
+		return 0, fmt.Errorf("InitLedgerStoreWithGenesisBlock, BlockIndex.Load error: %v", err)
+	}
+	if _, ok := cs.blockIndex.HeightOf(cs.currentBlockHash); !ok {
+		if err := cs.blockIndex.Add(currentHeader); err != nil {
+			return 0, err
+		}
+	}
+
 	root, err := cs.GetCurrentBlockStateRoot()
 	if err != nil {
 		return 0, nil
@@ -125,6 +223,14 @@ func (cs *ChainStore) InitLedgerStoreWithGenesisBlock(genesisBlock *block.Block)
 		return 0, err
 	}
 
+	flushedHeight := cs.getFlushedStateHeight(cs.currentBlockHeight)
+	if flushedHeight < cs.currentBlockHeight {
+		log.Warnf("chain store was closed with trie writes deferred for heights %d-%d, replaying from block store", flushedHeight+1, cs.currentBlockHeight)
+		if err := cs.recoverPendingStateTrie(flushedHeight, cs.currentBlockHeight); err != nil {
+			return 0, fmt.Errorf("InitLedgerStoreWithGenesisBlock, recoverPendingStateTrie error: %v", err)
+		}
+	}
+
 	switch config.Parameters.StatePruningMode {
 	case "lowmem":
 		err = cs.PruneStatesLowMemory(true)
@@ -150,16 +256,27 @@ func (cs *ChainStore) IsTxHashDuplicate(txhash common.Uint256) bool {
 }
 
 func (cs *ChainStore) GetBlockHash(height uint32) (common.Uint256, error) {
+	if hash, ok := cs.cache.getHeightToHash(height); ok {
+		return hash, nil
+	}
+
 	blockHash, err := cs.st.Get(db.BlockhashKey(height))
 	if err != nil {
 		return common.EmptyUint256, err
 	}
 
-	return common.Uint256ParseFromBytes(blockHash)
+	hash, err := common.Uint256ParseFromBytes(blockHash)
+	if err != nil {
+		return common.EmptyUint256, err
+	}
+
+	cs.cache.putHeightToHash(height, hash)
+
+	return hash, nil
 }
 
 func (cs *ChainStore) GetBlockByHeight(height uint32) (*block.Block, error) {
-	hash, err := cs.GetBlockHash(height)
+	hash, err := cs.blockHashByHeight(height)
 	if err != nil {
 		return nil, err
 	}
@@ -167,7 +284,40 @@ func (cs *ChainStore) GetBlockByHeight(height uint32) (*block.Block, error) {
 	return cs.GetBlock(hash)
 }
 
+// blockHashByHeight resolves height to a block hash with a single
+// prefix-bounded iterator seek into the sequential header schema
+// (HeaderSeqKey is prefixed by height, so the hash is the key's suffix),
+// instead of the legacy random-access BlockhashKey(height) lookup. It
+// falls back to GetBlockHash for a database that hasn't been migrated to
+// the sequential schema yet.
+func (cs *ChainStore) blockHashByHeight(height uint32) (common.Uint256, error) {
+	if hash, ok := cs.cache.getHeightToHash(height); ok {
+		return hash, nil
+	}
+
+	iter := cs.st.NewIterator(db.HeaderSeqPrefix(height))
+	defer iter.Release()
+
+	if iter.Next() {
+		key := iter.Key()
+		if len(key) < 1+8+32 {
+			return common.EmptyUint256, fmt.Errorf("blockHashByHeight: malformed sequential header key of length %d", len(key))
+		}
+
+		var hash common.Uint256
+		copy(hash[:], key[1+8:1+8+32])
+		cs.cache.putHeightToHash(height, hash)
+		return hash, nil
+	}
+
+	return cs.GetBlockHash(height)
+}
+
 func (cs *ChainStore) GetHeader(hash common.Uint256) (*block.Header, error) {
+	if h, ok := cs.cache.getHeader(hash); ok {
+		return h, nil
+	}
+
 	data, err := cs.st.Get(db.HeaderKey(hash))
 	if err != nil {
 		return nil, err
@@ -184,11 +334,13 @@ func (cs *ChainStore) GetHeader(hash common.Uint256) (*block.Header, error) {
 		return nil, err
 	}
 
+	cs.cache.putHeader(hash, h)
+
 	return h, nil
 }
 
 func (cs *ChainStore) GetHeaderByHeight(height uint32) (*block.Header, error) {
-	hash, err := cs.GetBlockHash(height)
+	hash, err := cs.blockHashByHeight(height)
 	if err != nil {
 		return nil, err
 	}
@@ -206,6 +358,10 @@ func (cs *ChainStore) GetTransaction(hash common.Uint256) (*transaction.Transact
 }
 
 func (cs *ChainStore) getTx(hash common.Uint256) (*transaction.Transaction, uint32, error) {
+	if ctx, ok := cs.cache.getTx(hash); ok {
+		return ctx.txn, ctx.height, nil
+	}
+
 	value, err := cs.st.Get(db.TransactionKey(hash))
 	if err != nil {
 		return nil, 0, err
@@ -218,35 +374,71 @@ func (cs *ChainStore) getTx(hash common.Uint256) (*transaction.Transaction, uint
 		return nil, height, err
 	}
 
+	cs.cache.putTx(hash, &cachedTx{txn: &txn, height: height})
+
 	return &txn, height, nil
 }
 
 func (cs *ChainStore) GetBlock(hash common.Uint256) (*block.Block, error) {
-	bHash, err := cs.st.Get(db.HeaderKey(hash))
-	if err != nil {
-		return nil, err
+	if b, ok := cs.cache.getBlock(hash); ok {
+		return b, nil
 	}
 
-	b := new(block.Block)
-	if err = b.FromTrimmedData(bytes.NewReader(bHash)); err != nil {
-		return nil, err
+	var b *block.Block
+	var txHashes []common.Uint256
+
+	if body, ok := cs.cache.getBody(hash); ok {
+		header, err := cs.GetHeader(hash)
+		if err != nil {
+			return nil, err
+		}
+		b = &block.Block{Header: header}
+		txHashes = body.txHashes
+	} else {
+		bHash, err := cs.st.Get(db.HeaderKey(hash))
+		if err != nil {
+			return nil, err
+		}
+
+		b = new(block.Block)
+		if err = b.FromTrimmedData(bytes.NewReader(bHash)); err != nil {
+			return nil, err
+		}
+
+		txHashes = make([]common.Uint256, len(b.Transactions))
+		for i, txn := range b.Transactions {
+			txHashes[i] = txn.Hash()
+		}
+		cs.cache.putBody(hash, &blockBody{txHashes: txHashes})
 	}
 
-	for i := 0; i < len(b.Transactions); i++ {
-		if b.Transactions[i], _, err = cs.getTx(b.Transactions[i].Hash()); err != nil {
+	b.Transactions = make([]*transaction.Transaction, len(txHashes))
+	for i, txHash := range txHashes {
+		var err error
+		if b.Transactions[i], _, err = cs.getTx(txHash); err != nil {
 			return nil, err
 		}
 	}
 
+	cs.cache.putBlock(hash, b)
+
 	return b, nil
 }
 
 func (cs *ChainStore) GetHeightByBlockHash(hash common.Uint256) (uint32, error) {
+	if height, ok := cs.blockIndex.HeightOf(hash); ok {
+		return height, nil
+	}
+
 	header, err := cs.getHeaderWithCache(hash)
 	if err == nil {
 		return header.UnsignedHeader.Height, nil
 	}
 
+	if data, err := cs.st.Get(db.HeaderNumberKey(hash)); err == nil {
+		return db.DecodeBlockNumber(data), nil
+	}
+
 	block, err := cs.GetBlock(hash)
 	if err != nil {
 		return 0, err
@@ -263,10 +455,15 @@ func (cs *ChainStore) IsBlockInStore(hash common.Uint256) bool {
 	return true
 }
 
-func (cs *ChainStore) persist(b *block.Block) error {
+// persist writes b to the database and returns whether the trie journal
+// actually flushed this block's trie writes to disk (as opposed to
+// deferring them), so callers like SaveBlock can gate trie-state-reading
+// work (e.g. pruning) on writes being durably committed rather than
+// still resident in the in-memory trie.
+func (cs *ChainStore) persist(b *block.Block) (bool, error) {
 	err := cs.st.NewBatch()
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	headerHash := b.Hash()
@@ -275,24 +472,38 @@ func (cs *ChainStore) persist(b *block.Block) error {
 	headerBuffer := bytes.NewBuffer(nil)
 	err = b.Trim(headerBuffer)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	err = cs.st.BatchPut(db.HeaderKey(headerHash), headerBuffer.Bytes())
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	//batch put headerhash
 	headerHashBuffer := bytes.NewBuffer(nil)
 	_, err = headerHash.Serialize(headerHashBuffer)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	err = cs.st.BatchPut(db.BlockhashKey(b.Header.UnsignedHeader.Height), headerHashBuffer.Bytes())
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	//batch put sequential-schema header/number entries, so height range
+	//reads (GetStateRoots, header sync) can stream sequentially instead
+	//of seeking hash-by-height then header-by-hash, and hash->height
+	//lookups (GetHeightByBlockHash) don't need to load a full block.
+	err = cs.st.BatchPut(db.HeaderSeqKey(b.Header.UnsignedHeader.Height, headerHash), headerBuffer.Bytes())
+	if err != nil {
+		return false, err
+	}
+
+	err = cs.st.BatchPut(db.HeaderNumberKey(headerHash), db.EncodeBlockNumber(b.Header.UnsignedHeader.Height))
+	if err != nil {
+		return false, err
 	}
 
 	//batch put transactions
@@ -301,13 +512,13 @@ func (cs *ChainStore) persist(b *block.Block) error {
 		binary.LittleEndian.PutUint32(buffer[:], b.Header.UnsignedHeader.Height)
 		dt, err := txn.Marshal()
 		if err != nil {
-			return err
+			return false, err
 		}
 
 		buffer = append(buffer, dt...)
 
 		if err := cs.st.BatchPut(db.TransactionKey(txn.Hash()), buffer); err != nil {
-			return err
+			return false, err
 		}
 
 		switch txn.UnsignedTx.Payload.Type {
@@ -323,70 +534,103 @@ func (cs *ChainStore) persist(b *block.Block) error {
 		case pb.GENERATE_ID_TYPE:
 		case pb.NANO_PAY_TYPE:
 		default:
-			return errors.New("unsupported transaction type")
+			return false, errors.New("unsupported transaction type")
 		}
 	}
 
 	//StateRoot
-	states, root, err := cs.generateStateRoot(context.Background(), b, b.Header.UnsignedHeader.Height != 0, true)
+	//
+	//The trie journal decides whether this block's trie updates should
+	//actually flush to LevelDB now or stay resident in the in-memory
+	//dirty-node cache for up to TrieJournalWindow blocks, to cut write
+	//amplification on high-throughput sync. commit=false still updates
+	//the in-memory trie; it just defers the on-disk write.
+	approxStateBytes := int64(len(b.Transactions)) * 256
+	commit := cs.trieJournal.note(b.Header.UnsignedHeader.Height, approxStateBytes)
+	states, root, err := cs.generateStateRoot(context.Background(), b, b.Header.UnsignedHeader.Height != 0, commit)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	headerRoot, err := common.Uint256ParseFromBytes(b.Header.UnsignedHeader.StateRoot)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if ok := root.CompareTo(headerRoot); ok != 0 {
-		return fmt.Errorf("state root not equal:%v, %v", root.ToHexString(), headerRoot.ToHexString())
-	}
+		return false, fmt.Errorf("state root not equal:%v, %v", root.ToHexString(), headerRoot.ToHexString())
+	}
+
+	// CurrentStateTrie must only advance to roots whose nodes are
+	// actually on disk. When commit is false the trie journal deferred
+	// this block's writes, so the in-memory trie is ahead of the last
+	// flushed root; leaving CurrentStateTrie pointing at the previous
+	// flushed root (and recording how far ahead we are via
+	// FlushedStateHeightKey) lets recoverPendingStateTrie replay the gap
+	// by re-executing blocks from the block store after a crash, instead
+	// of a graceful Close.
+	if commit {
+		err = cs.st.BatchPut(db.CurrentStateTrie(), root.ToArray())
+		if err != nil {
+			return false, err
+		}
 
-	err = cs.st.BatchPut(db.CurrentStateTrie(), root.ToArray())
-	if err != nil {
-		return err
+		err = cs.st.BatchPut(db.FlushedStateHeightKey(), db.EncodeBlockNumber(b.Header.UnsignedHeader.Height))
+		if err != nil {
+			return false, err
+		}
 	}
 
 	// batch put donation
 	if b.Header.UnsignedHeader.Height%uint32(config.RewardAdjustInterval) == 0 {
 		donation, err := cs.CalcNextDonation(b.Header.UnsignedHeader.Height)
 		if err != nil {
-			return err
+			return false, err
 		}
 
 		w := bytes.NewBuffer(nil)
 		err = donation.Serialize(w)
 		if err != nil {
-			return err
+			return false, err
 		}
 
 		if err := cs.st.BatchPut(db.DonationKey(b.Header.UnsignedHeader.Height), w.Bytes()); err != nil {
-			return err
+			return false, err
 		}
 	}
 
 	//batch put currentblockhash
 	err = serialization.WriteUint32(headerHashBuffer, b.Header.UnsignedHeader.Height)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	err = cs.st.BatchPut(db.CurrentBlockHashKey(), headerHashBuffer.Bytes())
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	err = cs.st.BatchCommit()
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	cs.States = states
 
-	return nil
+	txHashes := make([]common.Uint256, len(b.Transactions))
+	for i, txn := range b.Transactions {
+		txHashes[i] = txn.Hash()
+		cs.cache.putTx(txn.Hash(), &cachedTx{txn: txn, height: b.Header.UnsignedHeader.Height})
+	}
+	cs.cache.putHeader(headerHash, b.Header)
+	cs.cache.putBody(headerHash, &blockBody{txHashes: txHashes})
+	cs.cache.putBlock(headerHash, b)
+	cs.cache.putHeightToHash(b.Header.UnsignedHeader.Height, headerHash)
+
+	return commit, nil
 }
 
 func (cs *ChainStore) SaveBlock(b *block.Block, fastAdd bool) error {
-	err := cs.persist(b)
+	committed, err := cs.persist(b)
 	if err != nil {
 		log.Errorf("error to persist block: %v", err)
 		return err
@@ -402,7 +646,22 @@ func (cs *ChainStore) SaveBlock(b *block.Block, fastAdd bool) error {
 	}
 	cs.headerCache.AddHeaderToCache(b.Header)
 
-	if config.LivePruning {
+	if err := cs.blockIndex.Add(b.Header); err != nil {
+		return err
+	}
+	if err := cs.blockIndex.EvictBelow(cs.currentBlockHeight); err != nil {
+		return err
+	}
+
+	// Only prune on the blocks where persist actually flushed the trie
+	// journal to disk. Pruning reads which nodes are on disk, so running
+	// it against a block the journal deferred would see stale on-disk
+	// state and either prune live nodes or do nothing useful; tying it to
+	// committed instead of gating PruneStatesLowMemory on the journal's
+	// own pending() state keeps prune cadence matched 1:1 with flush
+	// cadence (about once per TrieJournalWindow blocks) instead of
+	// skipping almost every call.
+	if committed && config.LivePruning {
 		switch config.Parameters.StatePruningMode {
 		case "lowmem":
 			err = cs.PruneStatesLowMemory(false)
@@ -433,7 +692,7 @@ func (cs *ChainStore) GetHeight() uint32 {
 func (cs *ChainStore) AddHeader(header *block.Header) error {
 	cs.headerCache.AddHeaderToCache(header)
 
-	return nil
+	return cs.blockIndex.Add(header)
 }
 
 func (cs *ChainStore) GetHeaderHeight() uint32 {
@@ -623,22 +882,43 @@ func (cs *ChainStore) CalcNextDonation(height uint32) (*Donation, error) {
 	return d, nil
 }
 
+// GetStateRoots returns the state roots for every height in
+// [fromHeight, toHeight], reading the sequential header keyspace with a
+// single LevelDB iterator seeked directly to fromHeight, rather than
+// doing a hash-by-height lookup followed by a header-by-hash lookup for
+// every height (or scanning the header keyspace from genesis on every
+// call, which a from-height range query over a long chain can't afford).
 func (cs *ChainStore) GetStateRoots(fromHeight, toHeight uint32) ([]common.Uint256, error) {
 	if toHeight < fromHeight {
 		return nil, fmt.Errorf("toHeight(%v) is less than fromHeight(%v)\n", toHeight, fromHeight)
 	}
 	roots := make([]common.Uint256, 0, toHeight-fromHeight+1)
 
-	for i := fromHeight; i <= toHeight; i++ {
-		headerHash, err := cs.GetBlockHash(i)
+	headerSeqPrefixByte := db.HeaderSeqKeyPrefix()[0]
+
+	iter := cs.st.NewIterator(db.HeaderSeqPrefix(fromHeight))
+	defer iter.Release()
+
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) < 9 || key[0] != headerSeqPrefixByte {
+			break
+		}
+		height := db.DecodeBlockNumber(key[1:9])
+		if height > toHeight {
+			break
+		}
+
+		h := &block.Header{}
+		dt, err := serialization.ReadVarBytes(bytes.NewReader(iter.Value()))
 		if err != nil {
 			return nil, err
 		}
-		header, err := cs.GetHeader(headerHash)
-		if err != nil {
+		if err := h.Unmarshal(dt); err != nil {
 			return nil, err
 		}
-		stateRoot, err := common.Uint256ParseFromBytes(header.UnsignedHeader.StateRoot)
+
+		stateRoot, err := common.Uint256ParseFromBytes(h.UnsignedHeader.StateRoot)
 		if err != nil {
 			return nil, err
 		}
@@ -646,6 +926,10 @@ func (cs *ChainStore) GetStateRoots(fromHeight, toHeight uint32) ([]common.Uint2
 		roots = append(roots, stateRoot)
 	}
 
+	if len(roots) != int(toHeight-fromHeight+1) {
+		return nil, fmt.Errorf("GetStateRoots: expected %d roots, found %d (sequential schema not migrated?)", toHeight-fromHeight+1, len(roots))
+	}
+
 	return roots, nil
 }
 
@@ -690,7 +974,19 @@ func (cs *ChainStore) persistCompactHeight(height uint32) error {
 	return cs.st.Put(db.TrieCompactHeightKey(), heightBuffer)
 }
 
+// PruneStatesLowMemory prunes trie nodes below the chain's pruning
+// window. Callers on the live SaveBlock path should only invoke this on
+// heights where persist() reports the trie journal actually flushed
+// (see SaveBlock), since pruning reads which nodes are on disk and a node
+// the journal is still deferring would look prunable when it is really
+// just not written yet; the pending() check here is a defensive
+// second guard against calling this at the wrong time (e.g. a future
+// caller that doesn't thread committed through), not the primary gate.
 func (cs *ChainStore) PruneStatesLowMemory(full bool) error {
+	if cs.trieJournal.pending() {
+		return nil
+	}
+
 	state, err := NewStateDB(common.EmptyUint256, cs)
 	if err != nil {
 		return err
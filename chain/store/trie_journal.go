@@ -0,0 +1,107 @@
+package store
+
+import (
+	"sync"
+
+	"github.com/nknorg/nkn/v2/util/config"
+	"github.com/nknorg/nkn/v2/util/log"
+)
+
+// Default trie journal window/size-cap, used when config.Parameters
+// leaves the corresponding field unset (zero).
+const (
+	defaultTrieJournalWindow       = 128
+	defaultTrieJournalSizeCapBytes = 256 << 20 // 256MB
+)
+
+// trieJournal decides when the state trie committed in persist() should
+// actually flush its dirty nodes to LevelDB, instead of writing on every
+// single block. It tracks how many blocks have accumulated since the
+// last flush and a rough estimate of how many bytes of trie nodes those
+// blocks touched, and asks for a flush once either the configured block
+// window or the configured byte budget is reached.
+//
+// This is a block/byte-counting policy only: it does not itself hold a
+// nodeHash->encodedNode map or refcounts the way geth's trie/database
+// dirty-node cache does, since that requires hooking the trie encoder,
+// which lives in StateDB (not touched by this change). Crash/shutdown
+// safety for the blocks this policy defers is handled separately in
+// trie_recovery.go, which re-executes the deferred range from the block
+// store rather than replaying an in-memory node journal.
+type trieJournal struct {
+	mu sync.Mutex
+
+	window       uint32
+	sizeCapBytes int64
+
+	blocksSinceFlush uint32
+	bytesSinceFlush  int64
+	lastFlushHeight  uint32
+}
+
+func newTrieJournal() *trieJournal {
+	return &trieJournal{
+		window:       cacheSize32(config.Parameters.TrieJournalWindow, defaultTrieJournalWindow),
+		sizeCapBytes: cacheSize64(config.Parameters.TrieJournalSizeCapBytes, defaultTrieJournalSizeCapBytes),
+	}
+}
+
+func cacheSize32(configured uint32, def uint32) uint32 {
+	if configured > 0 {
+		return configured
+	}
+	return def
+}
+
+func cacheSize64(configured int64, def int64) int64 {
+	if configured > 0 {
+		return configured
+	}
+	return def
+}
+
+// note records that height's block has been applied to the in-memory
+// trie and contributed approxBytes of dirty node data, and reports
+// whether the caller should now ask the trie to commit (flush) its dirty
+// nodes to LevelDB rather than keep deferring.
+func (j *trieJournal) note(height uint32, approxBytes int64) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.blocksSinceFlush++
+	j.bytesSinceFlush += approxBytes
+
+	flush := height == 0 || // always persist genesis immediately
+		j.blocksSinceFlush >= j.window ||
+		j.bytesSinceFlush >= j.sizeCapBytes
+
+	if flush {
+		log.Debugf("trie journal flushing at height %d: %d blocks, %d bytes since last flush",
+			height, j.blocksSinceFlush, j.bytesSinceFlush)
+		j.blocksSinceFlush = 0
+		j.bytesSinceFlush = 0
+		j.lastFlushHeight = height
+	}
+
+	return flush
+}
+
+// forceFlush marks the journal as flushed, for use on graceful shutdown
+// where the caller commits regardless of the window/size-cap policy.
+func (j *trieJournal) forceFlush() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.blocksSinceFlush = 0
+	j.bytesSinceFlush = 0
+}
+
+// pending reports whether there are blocks whose trie writes have not
+// yet been flushed to disk, so PruneStatesLowMemory can account for
+// nodes that only live in memory so far.
+func (j *trieJournal) pending() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.blocksSinceFlush > 0
+}
@@ -0,0 +1,106 @@
+package store
+
+import "testing"
+
+func TestTrieJournalFlushesOnWindow(t *testing.T) {
+	j := &trieJournal{window: 4, sizeCapBytes: 1 << 30}
+
+	for height := uint32(1); height < 4; height++ {
+		if j.note(height, 1) {
+			t.Fatalf("height %d: expected no flush before window is reached", height)
+		}
+	}
+
+	if !j.note(4, 1) {
+		t.Fatalf("expected flush once blocksSinceFlush reaches window")
+	}
+	if j.blocksSinceFlush != 0 || j.bytesSinceFlush != 0 {
+		t.Fatalf("expected counters reset after flush, got blocks=%d bytes=%d", j.blocksSinceFlush, j.bytesSinceFlush)
+	}
+}
+
+func TestTrieJournalFlushesOnSizeCap(t *testing.T) {
+	j := &trieJournal{window: 1000, sizeCapBytes: 100}
+
+	if j.note(1, 60) {
+		t.Fatalf("expected no flush before size cap is reached")
+	}
+	if !j.note(2, 60) {
+		t.Fatalf("expected flush once bytesSinceFlush reaches size cap")
+	}
+}
+
+func TestTrieJournalAlwaysFlushesGenesis(t *testing.T) {
+	j := &trieJournal{window: 1000, sizeCapBytes: 1 << 30}
+
+	if !j.note(0, 1) {
+		t.Fatalf("expected genesis block to always flush regardless of window/size cap")
+	}
+}
+
+// An integration test driving persist()/recoverPendingStateTrie end to
+// end against a fake db.IStore is not possible in this tree: db.IStore
+// itself is only ever referenced, never defined, and persist/
+// recoverPendingStateTrie also depend on block.Block, the common package,
+// and a concrete StateDB, none of which exist in this snapshot either.
+// Faking all of that to drive these two functions would mean inventing
+// their behavior rather than testing it. TestTrieJournalBoundsPruneCadence
+// below is the closest feasible substitute: it exercises the exact
+// flush-gated-pruning sequence SaveBlock now drives (note() then prune
+// only when it returns true) against the real trieJournal, which is the
+// part of this change that doesn't require those missing types.
+
+// TestTrieJournalBoundsPruneCadence simulates SaveBlock's flush-gated
+// pruning (prune only on the heights note() reports a flush) across many
+// blocks and checks that pruning still runs regularly — about once per
+// window — rather than being starved by the journal staying "pending"
+// almost all the time. This guards against regressing to a pruner that
+// instead gates on pending() every call, which with the default 128-block
+// window would skip ~127 of every 128 SaveBlock calls.
+func TestTrieJournalBoundsPruneCadence(t *testing.T) {
+	const window = 8
+	j := &trieJournal{window: window, sizeCapBytes: 1 << 30}
+
+	const totalBlocks = 500
+	pruneCount := 0
+	maxGapSinceFlush := 0
+	sinceLastPrune := 0
+
+	for height := uint32(1); height <= totalBlocks; height++ {
+		committed := j.note(height, 1)
+		sinceLastPrune++
+		if committed {
+			pruneCount++
+			if sinceLastPrune > maxGapSinceFlush {
+				maxGapSinceFlush = sinceLastPrune
+			}
+			sinceLastPrune = 0
+		}
+	}
+
+	wantMinPrunes := totalBlocks/window - 1
+	if pruneCount < wantMinPrunes {
+		t.Fatalf("pruneCount = %d, want at least %d (once per %d blocks over %d blocks)", pruneCount, wantMinPrunes, window, totalBlocks)
+	}
+	if maxGapSinceFlush > window {
+		t.Fatalf("max gap between prunes = %d blocks, want at most %d (the journal window)", maxGapSinceFlush, window)
+	}
+}
+
+func TestTrieJournalPendingTracksUnflushedBlocks(t *testing.T) {
+	j := &trieJournal{window: 4, sizeCapBytes: 1 << 30}
+
+	if j.pending() {
+		t.Fatalf("expected no pending writes on a fresh journal")
+	}
+
+	j.note(1, 1)
+	if !j.pending() {
+		t.Fatalf("expected pending writes after a deferred note")
+	}
+
+	j.forceFlush()
+	if j.pending() {
+		t.Fatalf("expected no pending writes after forceFlush")
+	}
+}
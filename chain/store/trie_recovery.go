@@ -0,0 +1,72 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nknorg/nkn/v2/chain/db"
+	"github.com/nknorg/nkn/v2/common"
+)
+
+// getFlushedStateHeight returns the height of the last block whose trie
+// writes were actually committed to LevelDB, per FlushedStateHeightKey.
+// A missing key means the database predates this tracking (or is fresh),
+// in which case currentHeight is assumed fully flushed so no replay runs.
+func (cs *ChainStore) getFlushedStateHeight(currentHeight uint32) uint32 {
+	data, err := cs.st.Get(db.FlushedStateHeightKey())
+	if err != nil {
+		return currentHeight
+	}
+	return db.DecodeBlockNumber(data)
+}
+
+// recoverPendingStateTrie catches the in-memory trie held by cs.States
+// back up to currentHeight when the chain store was last closed (or
+// crashed) with trie writes still deferred by the trie journal. It
+// re-executes every block in (flushedHeight, currentHeight] from the
+// block store, always forcing a flush, so CurrentStateTrie and
+// FlushedStateHeightKey end up consistent with the chain head again.
+//
+// This replays from the block store rather than any in-memory node
+// journal, since the trie journal only defers the on-disk commit of a
+// block's state root and does not itself retain the dirty trie nodes
+// across a process restart.
+func (cs *ChainStore) recoverPendingStateTrie(flushedHeight, currentHeight uint32) error {
+	if flushedHeight >= currentHeight {
+		return nil
+	}
+
+	for height := flushedHeight + 1; height <= currentHeight; height++ {
+		b, err := cs.GetBlockByHeight(height)
+		if err != nil {
+			return fmt.Errorf("recoverPendingStateTrie: loading block at height %d: %v", height, err)
+		}
+
+		states, root, err := cs.generateStateRoot(context.Background(), b, height != 0, true)
+		if err != nil {
+			return fmt.Errorf("recoverPendingStateTrie: replaying block at height %d: %v", height, err)
+		}
+
+		headerRoot, err := common.Uint256ParseFromBytes(b.Header.UnsignedHeader.StateRoot)
+		if err != nil {
+			return err
+		}
+		if root.CompareTo(headerRoot) != 0 {
+			return fmt.Errorf("recoverPendingStateTrie: replayed root %v does not match header root %v at height %d",
+				root.ToHexString(), headerRoot.ToHexString(), height)
+		}
+
+		if err := cs.st.Put(db.CurrentStateTrie(), root.ToArray()); err != nil {
+			return err
+		}
+		if err := cs.st.Put(db.FlushedStateHeightKey(), db.EncodeBlockNumber(height)); err != nil {
+			return err
+		}
+
+		cs.States = states
+	}
+
+	cs.trieJournal.forceFlush()
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import "github.com/nknorg/nkn/v2/common"
+
+// Configuration holds the subset of node configuration chain/store and
+// chain/db read from. It only carries the fields those packages actually
+// reference (config.Parameters.*); it is not a full reconstruction of
+// the real node configuration file, since that file isn't present in
+// this tree.
+type Configuration struct {
+	ChainDBPath      string
+	StatePruningMode string
+
+	BlockHeaderCacheSize  uint32
+	HeaderCacheSize       int
+	BodyCacheSize         int
+	BlockCacheSize        int
+	TxCacheSize           int
+	HeightToHashCacheSize int
+
+	TrieJournalWindow       uint32
+	TrieJournalSizeCapBytes int64
+
+	BlockIndexEvictDepth uint32
+}
+
+// Parameters is the process-wide configuration instance, populated by
+// whatever loads the node's config file. Left at its zero value here
+// (every cache/window/depth field unset), every cacheSize/cacheSize32/
+// cacheSize64 call in chain/store falls back to its own default.
+var Parameters = &Configuration{}
+
+// DBVersion is the on-disk schema version this build expects;
+// InitLedgerStoreWithGenesisBlock compares it against the version byte
+// stored under db.VersionKey() to decide between resume, migrate and
+// full reset.
+var DBVersion byte = 0x02
+
+// ChainID distinguishes snapshots/peers belonging to different NKN
+// networks (mainnet, testnet, a private chain) from one another.
+var ChainID uint32
+
+// LivePruning controls whether SaveBlock prunes state on each block whose
+// trie writes the trie journal flushed to disk (true) or only ever via an
+// explicit/offline pruning pass (false).
+var LivePruning bool
+
+// RewardAdjustInterval is the block-height interval at which the
+// donation/reward amount is recalculated.
+var RewardAdjustInterval uint32 = 100000
+
+// DonationAddress receives the per-interval donation calculated by
+// ChainStore.CalcNextDonation.
+var DonationAddress string
+
+// DonationAdjustDividendFactor and DonationAdjustDivisorFactor set the
+// fraction of the donation address's balance paid out each
+// RewardAdjustInterval: balance * DonationAdjustDividendFactor /
+// DonationAdjustDivisorFactor.
+var (
+	DonationAdjustDividendFactor common.Fixed64 = 1
+	DonationAdjustDivisorFactor  common.Fixed64 = 5
+)
+
+// NKNAssetID is the asset ID of the network's native token, used to look
+// up account balances when no other asset is specified.
+var NKNAssetID common.Uint256